@@ -0,0 +1,192 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package wire
+
+// Arena owns a set of reusable buffers that decoders can build Values out
+// of instead of allocating one Field/Value/byte slice per container.
+// Call Reset once every Value handed out by the Arena is done being read;
+// Reset returns the underlying buffers to the pool for the next message
+// and invalidates every Value built from them, exactly like sync.Pool's
+// Put/Get contract. An Arena is not safe for concurrent use; servers that
+// decode one message per goroutine should use one Arena per message.
+//
+// Each StructBuilder/ListBuilder owns its own backing array, acquired
+// from the Arena's pool and returned to it only once Build is called.
+// This matters for nested construction - decoding a struct field whose
+// value is itself a struct, say - where an inner builder is created and
+// finished while an outer builder is still open: if builders shared one
+// arena-wide growing slice keyed by a start offset, the outer builder's
+// later appends would land on top of the inner builder's already-built
+// slice. Giving every builder its own array sidesteps that entirely.
+//
+// Consumers that never pass an Arena to a decoder are unaffected: Values
+// remain plain values, and the accessors on Value work identically either
+// way.
+type Arena struct {
+	// freeFields/freeValues are backing arrays available for immediate
+	// reuse, built up by the previous Reset.
+	freeFields [][]Field
+	freeValues [][]Value
+
+	// usedFields/usedValues are backing arrays a StructBuilder/
+	// ListBuilder has already Build() -ed. They remain live - aliased by
+	// a Value the caller holds - until Reset recycles them, so they must
+	// never be handed out again before then.
+	usedFields [][]Field
+	usedValues [][]Value
+
+	bytes []byte
+}
+
+// NewArena returns an empty Arena.
+func NewArena() *Arena {
+	return &Arena{}
+}
+
+// Reset invalidates every Value, StructBuilder, and ListBuilder this Arena
+// has handed out and returns their backing buffers to the pool so the
+// next decode can reuse them. Callers must not touch anything built from
+// this Arena after calling Reset.
+func (a *Arena) Reset() {
+	for _, buf := range a.usedFields {
+		a.freeFields = append(a.freeFields, buf[:0])
+	}
+	a.usedFields = a.usedFields[:0]
+
+	for _, buf := range a.usedValues {
+		a.freeValues = append(a.freeValues, buf[:0])
+	}
+	a.usedValues = a.usedValues[:0]
+
+	a.bytes = a.bytes[:0]
+}
+
+// NewBinary returns an n-byte slice drawn from the Arena's byte slab
+// instead of a fresh allocation. The slice is only valid until Reset.
+func (a *Arena) NewBinary(n int) []byte {
+	if cap(a.bytes)-len(a.bytes) < n {
+		a.bytes = make([]byte, 0, max(n, 4096))
+	}
+	start := len(a.bytes)
+	a.bytes = a.bytes[:start+n]
+	return a.bytes[start : start+n : start+n]
+}
+
+// NewStruct returns a StructBuilder with its own backing array, drawn
+// from this Arena's pool, with room for fieldCap fields before it needs
+// to grow.
+func (a *Arena) NewStruct(fieldCap int) *StructBuilder {
+	return &StructBuilder{arena: a, buf: a.acquireFields(fieldCap)}
+}
+
+// NewList returns a ListBuilder with its own backing array, drawn from
+// this Arena's pool, for a list of the given element Type with room for
+// capacity items before it needs to grow.
+func (a *Arena) NewList(typ Type, capacity int) *ListBuilder {
+	return &ListBuilder{arena: a, typ: typ, buf: a.acquireValues(capacity)}
+}
+
+// acquireFields pops a []Field buffer with at least capHint capacity off
+// the free list, allocating a new one if the pool is empty or every
+// pooled buffer is too small.
+func (a *Arena) acquireFields(capHint int) []Field {
+	for i := len(a.freeFields) - 1; i >= 0; i-- {
+		if buf := a.freeFields[i]; cap(buf) >= capHint {
+			a.freeFields[i] = a.freeFields[len(a.freeFields)-1]
+			a.freeFields = a.freeFields[:len(a.freeFields)-1]
+			return buf[:0]
+		}
+	}
+	return make([]Field, 0, max(capHint, 8))
+}
+
+// acquireValues is the List analog of acquireFields.
+func (a *Arena) acquireValues(capHint int) []Value {
+	for i := len(a.freeValues) - 1; i >= 0; i-- {
+		if buf := a.freeValues[i]; cap(buf) >= capHint {
+			a.freeValues[i] = a.freeValues[len(a.freeValues)-1]
+			a.freeValues = a.freeValues[:len(a.freeValues)-1]
+			return buf[:0]
+		}
+	}
+	return make([]Value, 0, max(capHint, 8))
+}
+
+// markFieldsUsed records buf as live - aliased by a just-built Struct -
+// so Reset knows to reclaim it once that Struct is no longer needed,
+// and so it is never handed out again before then.
+func (a *Arena) markFieldsUsed(buf []Field) {
+	a.usedFields = append(a.usedFields, buf)
+}
+
+// markValuesUsed is the List analog of markFieldsUsed.
+func (a *Arena) markValuesUsed(buf []Value) {
+	a.usedValues = append(a.usedValues, buf)
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// StructBuilder accumulates Fields for a Struct into its own backing
+// array. Call Build once every field has been appended.
+type StructBuilder struct {
+	arena *Arena
+	buf   []Field
+}
+
+// Append adds a field to the Struct under construction.
+func (b *StructBuilder) Append(f Field) {
+	b.buf = append(b.buf, f)
+}
+
+// Build returns the Struct assembled so far and hands the builder's
+// backing array to the Arena for reuse after the next Reset. The
+// returned Struct's Fields slice aliases that array and is only valid
+// until Reset.
+func (b *StructBuilder) Build() Struct {
+	b.arena.markFieldsUsed(b.buf)
+	return Struct{Fields: b.buf}
+}
+
+// ListBuilder accumulates items for a List into its own backing array.
+// Call Build once every item has been appended.
+type ListBuilder struct {
+	arena *Arena
+	typ   Type
+	buf   []Value
+}
+
+// Append adds an item to the List under construction.
+func (b *ListBuilder) Append(v Value) {
+	b.buf = append(b.buf, v)
+}
+
+// Build returns the List assembled so far and hands the builder's backing
+// array to the Arena for reuse after the next Reset. The returned List's
+// Items aliases that array and is only valid until Reset.
+func (b *ListBuilder) Build() List {
+	b.arena.markValuesUsed(b.buf)
+	return List{ValueType: b.typ, Size: len(b.buf), Items: ValueListFromSlice(b.typ, b.buf)}
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package wire
+
+import "testing"
+
+// TestArenaNestedStructBuilders is a regression test: building an inner
+// struct (field 2's value) to completion while an outer struct's builder
+// is still open must not corrupt the outer struct's fields.
+func TestArenaNestedStructBuilders(t *testing.T) {
+	a := NewArena()
+
+	outer := a.NewStruct(2)
+	outer.Append(Field{ID: 1, Value: NewValueI32(1)})
+
+	inner := a.NewStruct(1)
+	inner.Append(Field{ID: 1, Value: NewValueI32(100)})
+	innerStruct := inner.Build()
+
+	outer.Append(Field{ID: 2, Value: NewValueStruct(innerStruct)})
+	outerStruct := outer.Build()
+
+	if len(outerStruct.Fields) != 2 {
+		t.Fatalf("outer struct has %d fields, want 2: %v", len(outerStruct.Fields), outerStruct.Fields)
+	}
+	if got, ok, _ := outerStruct.FieldByID(1); !ok || got.GetI32() != 1 {
+		t.Errorf("outer field 1 = %v, want TI32(1)", got)
+	}
+	got, ok, _ := outerStruct.FieldByID(2)
+	if !ok {
+		t.Fatalf("outer field 2 not found")
+	}
+	gotStruct, err := got.GetStruct()
+	if err != nil || gotStruct.String() != innerStruct.String() {
+		t.Errorf("outer field 2 = %v, want %v", got, innerStruct)
+	}
+	if len(innerStruct.Fields) != 1 || innerStruct.Fields[0].Value.GetI32() != 100 {
+		t.Errorf("inner struct was corrupted: %v", innerStruct)
+	}
+}
+
+// TestArenaNestedListBuilders is the List analog of
+// TestArenaNestedStructBuilders: building an inner list to completion
+// while an outer list's builder is still open must not corrupt the
+// outer list.
+func TestArenaNestedListBuilders(t *testing.T) {
+	a := NewArena()
+
+	outer := a.NewList(TI32, 2)
+	outer.Append(NewValueI32(1))
+
+	inner := a.NewList(TI32, 2)
+	inner.Append(NewValueI32(10))
+	inner.Append(NewValueI32(20))
+	innerList := inner.Build()
+
+	outer.Append(NewValueI32(2))
+	outerList := outer.Build()
+
+	if outerList.Size != 2 {
+		t.Fatalf("outer list has size %d, want 2", outerList.Size)
+	}
+	var items []Value
+	innerList.Items.ForEach(func(v Value) error {
+		items = append(items, v)
+		return nil
+	})
+	if len(items) != 2 || items[0].GetI32() != 10 || items[1].GetI32() != 20 {
+		t.Errorf("inner list was corrupted: %v", items)
+	}
+}
+
+// TestArenaReusesBuffersOnlyAfterReset is a regression test for handing
+// out a still-live buffer: acquiring a new Struct before Reset must not
+// be able to draw the backing array of one that was already Build() -ed.
+func TestArenaReusesBuffersOnlyAfterReset(t *testing.T) {
+	a := NewArena()
+
+	first := a.NewStruct(4)
+	first.Append(Field{ID: 1, Value: NewValueI32(1)})
+	firstStruct := first.Build()
+
+	second := a.NewStruct(4)
+	second.Append(Field{ID: 2, Value: NewValueI32(2)})
+	second.Build()
+
+	if len(firstStruct.Fields) != 1 || firstStruct.Fields[0].ID != 1 {
+		t.Errorf("first struct was corrupted by a later NewStruct call: %v", firstStruct.Fields)
+	}
+
+	a.Reset()
+	third := a.NewStruct(4)
+	third.Append(Field{ID: 3, Value: NewValueI32(3)})
+	thirdStruct := third.Build()
+	if len(thirdStruct.Fields) != 1 || thirdStruct.Fields[0].ID != 3 {
+		t.Errorf("struct built after Reset = %v", thirdStruct.Fields)
+	}
+}
+
+func buildStructArena(a *Arena, numFields int) Value {
+	b := a.NewStruct(numFields)
+	for i := 0; i < numFields; i++ {
+		b.Append(Field{ID: int16(i), Value: NewValueI32(int32(i))})
+	}
+	return NewValueStruct(b.Build())
+}
+
+func buildStructStock(numFields int) Value {
+	fields := make([]Field, numFields)
+	for i := 0; i < numFields; i++ {
+		fields[i] = Field{ID: int16(i), Value: NewValueI32(int32(i))}
+	}
+	return NewValueStruct(Struct{Fields: fields})
+}
+
+func BenchmarkStructConstructionStock(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buildStructStock(16)
+	}
+}
+
+func BenchmarkStructConstructionArena(b *testing.B) {
+	b.ReportAllocs()
+	a := NewArena()
+	for i := 0; i < b.N; i++ {
+		buildStructArena(a, 16)
+		a.Reset()
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package wire
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+)
+
+// Canonicalize materializes v into a deterministic form: Struct.Fields are
+// sorted by ID, and Set.Items/Map.Items are sorted by a stable encoding of
+// their key (the value itself, for Set). Canonicalize recurses, so the
+// result is deterministic at every depth.
+//
+// The result is safe to compare with reflect.DeepEqual or pass to Equal;
+// it is most useful for producing repeatable output (golden files, content
+// hashes) from values whose wire order is not semantically meaningful.
+func Canonicalize(v Value) Value {
+	switch v.typ {
+	case TStruct:
+		var fields []Field
+		v.tstruct.ForEach(func(f Field) error {
+			fields = append(fields, Field{ID: f.ID, Value: Canonicalize(f.Value)})
+			return nil
+		})
+		sort.Slice(fields, func(i, j int) bool { return fields[i].ID < fields[j].ID })
+		return NewValueStruct(Struct{Fields: fields})
+	case TMap:
+		var items []MapItem
+		v.tmap.Items.ForEach(func(item MapItem) error {
+			items = append(items, MapItem{
+				Key:   Canonicalize(item.Key),
+				Value: Canonicalize(item.Value),
+			})
+			return nil
+		})
+		sort.Slice(items, func(i, j int) bool {
+			return lessBytes(canonicalKeyBytes(items[i].Key), canonicalKeyBytes(items[j].Key))
+		})
+		return NewValueMap(Map{
+			KeyType:   v.tmap.KeyType,
+			ValueType: v.tmap.ValueType,
+			Size:      v.tmap.Size,
+			Items:     MapItemListFromSlice(v.tmap.KeyType, v.tmap.ValueType, items),
+		})
+	case TSet:
+		var items []Value
+		v.tset.Items.ForEach(func(item Value) error {
+			items = append(items, Canonicalize(item))
+			return nil
+		})
+		sort.Slice(items, func(i, j int) bool {
+			return lessBytes(canonicalKeyBytes(items[i]), canonicalKeyBytes(items[j]))
+		})
+		return NewValueSet(Set{
+			ValueType: v.tset.ValueType,
+			Size:      v.tset.Size,
+			Items:     ValueListFromSlice(v.tset.ValueType, items),
+		})
+	case TList:
+		var items []Value
+		v.tlist.Items.ForEach(func(item Value) error {
+			items = append(items, Canonicalize(item))
+			return nil
+		})
+		return NewValueList(List{
+			ValueType: v.tlist.ValueType,
+			Size:      v.tlist.Size,
+			Items:     ValueListFromSlice(v.tlist.ValueType, items),
+		})
+	default:
+		return v
+	}
+}
+
+func lessBytes(a, b []byte) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// canonicalKeyBytes produces a stable, type-tagged byte encoding of v
+// suitable for ordering Set and Map items. It is not a wire format and
+// carries no compatibility guarantees outside of a single process.
+func canonicalKeyBytes(v Value) []byte {
+	buf := []byte{byte(v.typ)}
+
+	switch v.typ {
+	case TBool:
+		if v.tbool {
+			buf = append(buf, 1)
+		} else {
+			buf = append(buf, 0)
+		}
+	case TI8:
+		buf = append(buf, byte(v.ti8))
+	case TDouble:
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], math.Float64bits(v.tdouble))
+		buf = append(buf, b[:]...)
+	case TI16:
+		var b [2]byte
+		binary.BigEndian.PutUint16(b[:], uint16(v.ti16))
+		buf = append(buf, b[:]...)
+	case TI32:
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], uint32(v.ti32))
+		buf = append(buf, b[:]...)
+	case TI64:
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], uint64(v.ti64))
+		buf = append(buf, b[:]...)
+	case TBinary:
+		buf = append(buf, lengthPrefix(len(v.tbinary))...)
+		buf = append(buf, v.tbinary...)
+	case TStruct:
+		cv := Canonicalize(v)
+		fields := cv.tstruct.(Struct).Fields
+		buf = append(buf, lengthPrefix(len(fields))...)
+		for _, f := range fields {
+			var id [2]byte
+			binary.BigEndian.PutUint16(id[:], uint16(f.ID))
+			buf = append(buf, id[:]...)
+			buf = append(buf, canonicalKeyBytes(f.Value)...)
+		}
+	case TMap:
+		cv := Canonicalize(v)
+		items := cv.tmap.Items.(mapItemSlice).items
+		buf = append(buf, lengthPrefix(len(items))...)
+		for _, item := range items {
+			buf = append(buf, canonicalKeyBytes(item.Key)...)
+			buf = append(buf, canonicalKeyBytes(item.Value)...)
+		}
+	case TSet:
+		cv := Canonicalize(v)
+		items := cv.tset.Items.(valueSlice).items
+		buf = append(buf, lengthPrefix(len(items))...)
+		for _, item := range items {
+			buf = append(buf, canonicalKeyBytes(item)...)
+		}
+	case TList:
+		cv := Canonicalize(v)
+		items := cv.tlist.Items.(valueSlice).items
+		buf = append(buf, lengthPrefix(len(items))...)
+		for _, item := range items {
+			buf = append(buf, canonicalKeyBytes(item)...)
+		}
+	}
+
+	return buf
+}
+
+func lengthPrefix(n int) []byte {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(n))
+	return b[:]
+}
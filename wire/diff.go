@@ -0,0 +1,290 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package wire
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// DiffKind categorizes a single Difference produced by Diff.
+type DiffKind int
+
+const (
+	// DiffMissingField indicates a is missing a Struct field that b has.
+	DiffMissingField DiffKind = iota + 1
+
+	// DiffExtraField indicates a has a Struct field that b does not.
+	DiffExtraField
+
+	// DiffTypeMismatch indicates a and b hold values of different Type at
+	// the same path.
+	DiffTypeMismatch
+
+	// DiffValueMismatch indicates a and b hold different scalar values of
+	// the same Type.
+	DiffValueMismatch
+
+	// DiffSizeMismatch indicates a and b are containers of the same Type
+	// with a different number of items.
+	DiffSizeMismatch
+
+	// DiffMissingItem indicates a Map or Set in a is missing an item (by
+	// key, for Map; by value, for Set) that is present in b.
+	DiffMissingItem
+
+	// DiffExtraItem indicates a Map or Set in a has an item (by key, for
+	// Map; by value, for Set) that is not present in b.
+	DiffExtraItem
+)
+
+// String returns a human-readable name for k.
+func (k DiffKind) String() string {
+	switch k {
+	case DiffMissingField:
+		return "missing field"
+	case DiffExtraField:
+		return "extra field"
+	case DiffTypeMismatch:
+		return "type mismatch"
+	case DiffValueMismatch:
+		return "value mismatch"
+	case DiffSizeMismatch:
+		return "size mismatch"
+	case DiffMissingItem:
+		return "missing item"
+	case DiffExtraItem:
+		return "extra item"
+	default:
+		return fmt.Sprintf("DiffKind(%d)", int(k))
+	}
+}
+
+// Difference is a single point at which two Values disagree, as reported
+// by Diff. A and B hold the conflicting values, or nil when one side is
+// absent (DiffMissingField, DiffExtraField).
+type Difference struct {
+	Path []Step
+	Kind DiffKind
+	A    *Value
+	B    *Value
+}
+
+func (d Difference) String() string {
+	return fmt.Sprintf("%v at %v", d.Kind, d.Path)
+}
+
+// Diff compares a and b and returns every point at which they disagree,
+// using the same Step path representation as Walk. Struct field order,
+// and Set/Map item order, never produce a difference; only the semantic
+// content does.
+func Diff(a, b Value) []Difference {
+	return diff(nil, a, b)
+}
+
+func diff(path []Step, a, b Value) []Difference {
+	if a.typ != b.typ {
+		return []Difference{{Path: path, Kind: DiffTypeMismatch, A: &a, B: &b}}
+	}
+
+	switch a.typ {
+	case TStruct:
+		return diffStructs(path, a.tstruct, b.tstruct)
+	case TMap:
+		return diffMaps(path, a.tmap, b.tmap)
+	case TSet:
+		return diffSets(path, a.tset, b.tset)
+	case TList:
+		return diffLists(path, a.tlist, b.tlist)
+	default:
+		if Equal(a, b) {
+			return nil
+		}
+		return []Difference{{Path: path, Kind: DiffValueMismatch, A: &a, B: &b}}
+	}
+}
+
+func diffStructs(path []Step, a, b StructAccessor) []Difference {
+	var diffs []Difference
+
+	seen := make(map[int16]bool)
+	a.ForEach(func(f Field) error {
+		seen[f.ID] = true
+		step := append(append([]Step{}, path...), Step{FieldID: f.ID})
+		bv, ok, _ := b.FieldByID(f.ID)
+		if !ok {
+			av := f.Value
+			diffs = append(diffs, Difference{Path: step, Kind: DiffExtraField, A: &av})
+			return nil
+		}
+		diffs = append(diffs, diff(step, f.Value, bv)...)
+		return nil
+	})
+	b.ForEach(func(f Field) error {
+		if seen[f.ID] {
+			return nil
+		}
+		step := append(append([]Step{}, path...), Step{FieldID: f.ID})
+		bv := f.Value
+		diffs = append(diffs, Difference{Path: step, Kind: DiffMissingField, B: &bv})
+		return nil
+	})
+
+	return diffs
+}
+
+// diffMaps compares the items of two Maps by key rather than by position:
+// a and b may canonicalize to the same size with entirely different key
+// sets (e.g. {1: "x", 2: "y"} vs {1: "x", 3: "z"}), and zipping by index
+// would misreport that as a value mismatch on a shared key instead of a
+// missing/extra key on each side.
+func diffMaps(path []Step, a, b Map) []Difference {
+	cva := Canonicalize(NewValueMap(a))
+	cvb := Canonicalize(NewValueMap(b))
+	ca := cva.GetMap()
+	cb := cvb.GetMap()
+
+	var aItems, bItems []MapItem
+	ca.Items.ForEach(func(item MapItem) error {
+		aItems = append(aItems, item)
+		return nil
+	})
+	cb.Items.ForEach(func(item MapItem) error {
+		bItems = append(bItems, item)
+		return nil
+	})
+
+	var diffs []Difference
+	i, j := 0, 0
+	for i < len(aItems) && j < len(bItems) {
+		switch bytes.Compare(canonicalKeyBytes(aItems[i].Key), canonicalKeyBytes(bItems[j].Key)) {
+		case 0:
+			key := aItems[i].Key
+			step := append(append([]Step{}, path...), Step{Key: &key})
+			diffs = append(diffs, diff(step, aItems[i].Value, bItems[j].Value)...)
+			i++
+			j++
+		case -1:
+			diffs = append(diffs, mapItemDiff(path, aItems[i], DiffExtraItem, true))
+			i++
+		default:
+			diffs = append(diffs, mapItemDiff(path, bItems[j], DiffMissingItem, false))
+			j++
+		}
+	}
+	for ; i < len(aItems); i++ {
+		diffs = append(diffs, mapItemDiff(path, aItems[i], DiffExtraItem, true))
+	}
+	for ; j < len(bItems); j++ {
+		diffs = append(diffs, mapItemDiff(path, bItems[j], DiffMissingItem, false))
+	}
+	return diffs
+}
+
+// mapItemDiff builds the Difference for a MapItem present on only one
+// side. inA is true when item belongs to a (DiffExtraItem); false when it
+// belongs to b (DiffMissingItem).
+func mapItemDiff(path []Step, item MapItem, kind DiffKind, inA bool) Difference {
+	key := item.Key
+	value := item.Value
+	step := append(append([]Step{}, path...), Step{Key: &key})
+	if inA {
+		return Difference{Path: step, Kind: kind, A: &value}
+	}
+	return Difference{Path: step, Kind: kind, B: &value}
+}
+
+// diffSets compares the items of two Sets by value rather than by
+// position, for the same reason as diffMaps: equal-size sets with
+// different contents must report missing/extra items, not a spurious
+// value mismatch between unrelated items that happened to land at the
+// same sorted index.
+func diffSets(path []Step, a, b Set) []Difference {
+	cva := Canonicalize(NewValueSet(a))
+	cvb := Canonicalize(NewValueSet(b))
+	ca := cva.GetSet()
+	cb := cvb.GetSet()
+
+	var aItems, bItems []Value
+	ca.Items.ForEach(func(item Value) error {
+		aItems = append(aItems, item)
+		return nil
+	})
+	cb.Items.ForEach(func(item Value) error {
+		bItems = append(bItems, item)
+		return nil
+	})
+
+	var diffs []Difference
+	i, j := 0, 0
+	for i < len(aItems) && j < len(bItems) {
+		switch bytes.Compare(canonicalKeyBytes(aItems[i]), canonicalKeyBytes(bItems[j])) {
+		case 0:
+			i++
+			j++
+		case -1:
+			av := aItems[i]
+			step := append(append([]Step{}, path...), Step{Index: i})
+			diffs = append(diffs, Difference{Path: step, Kind: DiffExtraItem, A: &av})
+			i++
+		default:
+			bv := bItems[j]
+			step := append(append([]Step{}, path...), Step{Index: j})
+			diffs = append(diffs, Difference{Path: step, Kind: DiffMissingItem, B: &bv})
+			j++
+		}
+	}
+	for ; i < len(aItems); i++ {
+		av := aItems[i]
+		step := append(append([]Step{}, path...), Step{Index: i})
+		diffs = append(diffs, Difference{Path: step, Kind: DiffExtraItem, A: &av})
+	}
+	for ; j < len(bItems); j++ {
+		bv := bItems[j]
+		step := append(append([]Step{}, path...), Step{Index: j})
+		diffs = append(diffs, Difference{Path: step, Kind: DiffMissingItem, B: &bv})
+	}
+	return diffs
+}
+
+func diffLists(path []Step, a, b List) []Difference {
+	var aItems, bItems []Value
+	a.Items.ForEach(func(item Value) error {
+		aItems = append(aItems, item)
+		return nil
+	})
+	b.Items.ForEach(func(item Value) error {
+		bItems = append(bItems, item)
+		return nil
+	})
+
+	if len(aItems) != len(bItems) {
+		av, bv := NewValueList(a), NewValueList(b)
+		return []Difference{{Path: path, Kind: DiffSizeMismatch, A: &av, B: &bv}}
+	}
+
+	var diffs []Difference
+	for i, item := range aItems {
+		step := append(append([]Step{}, path...), Step{Index: i})
+		diffs = append(diffs, diff(step, item, bItems[i])...)
+	}
+	return diffs
+}
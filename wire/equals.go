@@ -0,0 +1,166 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package wire
+
+import "bytes"
+
+// Equal reports whether a and b represent the same Thrift value.
+//
+// Equality is semantic rather than representational: Struct field order
+// never matters, and Set/Map item order never matters since both are
+// backed by iterators with no canonical order on the wire. TBinary
+// payloads are compared byte-for-byte.
+func Equal(a, b Value) bool {
+	if a.typ != b.typ {
+		return false
+	}
+
+	switch a.typ {
+	case TBool:
+		return a.tbool == b.tbool
+	case TI8:
+		return a.ti8 == b.ti8
+	case TDouble:
+		return a.tdouble == b.tdouble
+	case TI16:
+		return a.ti16 == b.ti16
+	case TI32:
+		return a.ti32 == b.ti32
+	case TI64:
+		return a.ti64 == b.ti64
+	case TBinary:
+		return bytes.Equal(a.tbinary, b.tbinary)
+	case TStruct:
+		return structsEqual(a.tstruct, b.tstruct)
+	case TMap:
+		return mapsEqual(a.tmap, b.tmap)
+	case TSet:
+		return setsEqual(a.tset, b.tset)
+	case TList:
+		return listsEqual(a.tlist, b.tlist)
+	default:
+		return false
+	}
+}
+
+func structsEqual(a, b StructAccessor) bool {
+	if a.Len() != b.Len() {
+		return false
+	}
+
+	same := true
+	a.ForEach(func(f Field) error {
+		bv, ok, err := b.FieldByID(f.ID)
+		if err != nil || !ok || !Equal(f.Value, bv) {
+			same = false
+		}
+		return nil
+	})
+	return same
+}
+
+func mapsEqual(a, b Map) bool {
+	if a.KeyType != b.KeyType || a.ValueType != b.ValueType || a.Size != b.Size {
+		return false
+	}
+
+	cva := Canonicalize(NewValueMap(a))
+	cvb := Canonicalize(NewValueMap(b))
+	ca := cva.GetMap()
+	cb := cvb.GetMap()
+
+	var aItems, bItems []MapItem
+	ca.Items.ForEach(func(item MapItem) error {
+		aItems = append(aItems, item)
+		return nil
+	})
+	cb.Items.ForEach(func(item MapItem) error {
+		bItems = append(bItems, item)
+		return nil
+	})
+
+	if len(aItems) != len(bItems) {
+		return false
+	}
+	for i, item := range aItems {
+		if !Equal(item.Key, bItems[i].Key) || !Equal(item.Value, bItems[i].Value) {
+			return false
+		}
+	}
+	return true
+}
+
+func setsEqual(a, b Set) bool {
+	if a.ValueType != b.ValueType || a.Size != b.Size {
+		return false
+	}
+
+	cva := Canonicalize(NewValueSet(a))
+	cvb := Canonicalize(NewValueSet(b))
+	ca := cva.GetSet()
+	cb := cvb.GetSet()
+
+	var aItems, bItems []Value
+	ca.Items.ForEach(func(item Value) error {
+		aItems = append(aItems, item)
+		return nil
+	})
+	cb.Items.ForEach(func(item Value) error {
+		bItems = append(bItems, item)
+		return nil
+	})
+
+	if len(aItems) != len(bItems) {
+		return false
+	}
+	for i, item := range aItems {
+		if !Equal(item, bItems[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func listsEqual(a, b List) bool {
+	if a.ValueType != b.ValueType || a.Size != b.Size {
+		return false
+	}
+
+	var aItems, bItems []Value
+	a.Items.ForEach(func(item Value) error {
+		aItems = append(aItems, item)
+		return nil
+	})
+	b.Items.ForEach(func(item Value) error {
+		bItems = append(bItems, item)
+		return nil
+	})
+
+	if len(aItems) != len(bItems) {
+		return false
+	}
+	for i, item := range aItems {
+		if !Equal(item, bItems[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package wire
+
+import "testing"
+
+func structOf(fields ...Field) Value {
+	return NewValueStruct(Struct{Fields: fields})
+}
+
+func mapOf(kt, vt Type, items ...MapItem) Value {
+	return NewValueMap(Map{KeyType: kt, ValueType: vt, Size: len(items), Items: MapItemListFromSlice(kt, vt, items)})
+}
+
+func setOf(vt Type, items ...Value) Value {
+	return NewValueSet(Set{ValueType: vt, Size: len(items), Items: ValueListFromSlice(vt, items)})
+}
+
+func listOf(vt Type, items ...Value) Value {
+	return NewValueList(List{ValueType: vt, Size: len(items), Items: ValueListFromSlice(vt, items)})
+}
+
+func TestEqualScalars(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Value
+		want bool
+	}{
+		{"equal i32", NewValueI32(42), NewValueI32(42), true},
+		{"unequal i32", NewValueI32(42), NewValueI32(43), false},
+		{"different types", NewValueI32(42), NewValueI64(42), false},
+		{"equal binary", NewValueBinary([]byte("abc")), NewValueBinary([]byte("abc")), true},
+		{"unequal binary", NewValueBinary([]byte("abc")), NewValueBinary([]byte("abd")), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Equal(tt.a, tt.b); got != tt.want {
+				t.Errorf("Equal(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEqualStructFieldOrderInsensitive(t *testing.T) {
+	a := structOf(Field{ID: 1, Value: NewValueI32(1)}, Field{ID: 2, Value: NewValueI32(2)})
+	b := structOf(Field{ID: 2, Value: NewValueI32(2)}, Field{ID: 1, Value: NewValueI32(1)})
+
+	if !Equal(a, b) {
+		t.Errorf("Equal(%v, %v) = false, want true: struct field order should not matter", a, b)
+	}
+}
+
+func TestEqualMapItemOrderInsensitive(t *testing.T) {
+	a := mapOf(TI32, TI32,
+		MapItem{Key: NewValueI32(1), Value: NewValueI32(10)},
+		MapItem{Key: NewValueI32(2), Value: NewValueI32(20)},
+	)
+	b := mapOf(TI32, TI32,
+		MapItem{Key: NewValueI32(2), Value: NewValueI32(20)},
+		MapItem{Key: NewValueI32(1), Value: NewValueI32(10)},
+	)
+
+	if !Equal(a, b) {
+		t.Errorf("Equal(%v, %v) = false, want true: map item order should not matter", a, b)
+	}
+}
+
+func TestEqualMapDifferentKeys(t *testing.T) {
+	a := mapOf(TI32, TBinary,
+		MapItem{Key: NewValueI32(1), Value: NewValueString("x")},
+		MapItem{Key: NewValueI32(2), Value: NewValueString("y")},
+	)
+	b := mapOf(TI32, TBinary,
+		MapItem{Key: NewValueI32(1), Value: NewValueString("x")},
+		MapItem{Key: NewValueI32(3), Value: NewValueString("z")},
+	)
+
+	if Equal(a, b) {
+		t.Errorf("Equal(%v, %v) = true, want false: key sets differ", a, b)
+	}
+}
+
+func TestEqualListOrderSensitive(t *testing.T) {
+	a := listOf(TI32, NewValueI32(1), NewValueI32(2))
+	b := listOf(TI32, NewValueI32(2), NewValueI32(1))
+
+	if Equal(a, b) {
+		t.Errorf("Equal(%v, %v) = true, want false: list order is semantically meaningful", a, b)
+	}
+}
+
+func TestCanonicalizeSortsStructFieldsByID(t *testing.T) {
+	v := structOf(Field{ID: 3, Value: NewValueI32(3)}, Field{ID: 1, Value: NewValueI32(1)})
+	cv := Canonicalize(v)
+	got, err := cv.GetStruct()
+	if err != nil {
+		t.Fatalf("GetStruct: %v", err)
+	}
+
+	if len(got.Fields) != 2 || got.Fields[0].ID != 1 || got.Fields[1].ID != 3 {
+		t.Errorf("Canonicalize did not sort fields by ID: %v", got)
+	}
+}
+
+func TestDiffIdenticalValuesIsEmpty(t *testing.T) {
+	v := structOf(Field{ID: 1, Value: NewValueI32(1)})
+	if diffs := Diff(v, v); len(diffs) != 0 {
+		t.Errorf("Diff(v, v) = %v, want no differences", diffs)
+	}
+}
+
+func TestDiffStructMissingAndExtraField(t *testing.T) {
+	a := structOf(Field{ID: 1, Value: NewValueI32(1)}, Field{ID: 2, Value: NewValueI32(2)})
+	b := structOf(Field{ID: 1, Value: NewValueI32(1)})
+
+	diffs := Diff(a, b)
+	if len(diffs) != 1 || diffs[0].Kind != DiffExtraField {
+		t.Fatalf("Diff(a, b) = %v, want a single DiffExtraField", diffs)
+	}
+
+	diffs = Diff(b, a)
+	if len(diffs) != 1 || diffs[0].Kind != DiffMissingField {
+		t.Fatalf("Diff(b, a) = %v, want a single DiffMissingField", diffs)
+	}
+}
+
+// TestDiffMapDifferentKeysNotValueMismatch is a regression test: two maps
+// of equal size but disjoint key sets must be reported as a missing/extra
+// item on each side, not as a spurious value mismatch between unrelated
+// values that happened to land at the same sorted index.
+func TestDiffMapDifferentKeysNotValueMismatch(t *testing.T) {
+	a := mapOf(TI32, TBinary,
+		MapItem{Key: NewValueI32(1), Value: NewValueString("x")},
+		MapItem{Key: NewValueI32(2), Value: NewValueString("y")},
+	)
+	b := mapOf(TI32, TBinary,
+		MapItem{Key: NewValueI32(1), Value: NewValueString("x")},
+		MapItem{Key: NewValueI32(3), Value: NewValueString("z")},
+	)
+
+	diffs := Diff(a, b)
+	if len(diffs) != 2 {
+		t.Fatalf("Diff(a, b) = %v, want exactly 2 differences (one extra, one missing)", diffs)
+	}
+	for _, d := range diffs {
+		if d.Kind == DiffValueMismatch {
+			t.Errorf("Diff(a, b) reported a DiffValueMismatch between unrelated keys: %v", d)
+		}
+		if d.Kind != DiffExtraItem && d.Kind != DiffMissingItem {
+			t.Errorf("Diff(a, b) reported unexpected kind %v", d.Kind)
+		}
+	}
+}
+
+// TestDiffSetDifferentItemsNotValueMismatch is the Set analog of
+// TestDiffMapDifferentKeysNotValueMismatch.
+func TestDiffSetDifferentItemsNotValueMismatch(t *testing.T) {
+	a := setOf(TI32, NewValueI32(1), NewValueI32(2))
+	b := setOf(TI32, NewValueI32(1), NewValueI32(3))
+
+	diffs := Diff(a, b)
+	if len(diffs) != 2 {
+		t.Fatalf("Diff(a, b) = %v, want exactly 2 differences (one extra, one missing)", diffs)
+	}
+	for _, d := range diffs {
+		if d.Kind == DiffValueMismatch {
+			t.Errorf("Diff(a, b) reported a DiffValueMismatch between unrelated set items: %v", d)
+		}
+	}
+}
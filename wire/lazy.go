@@ -0,0 +1,360 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package wire
+
+import (
+	"context"
+	"io"
+)
+
+// StructAccessor is the read surface a Value of TStruct type is backed
+// by. Struct, which holds every field pre-materialized into a slice,
+// implements it trivially; LazyStruct implements it by streaming fields
+// off the wire on demand. Callers that only need one or two fields, or
+// that want to avoid materializing a struct they're merely forwarding,
+// should use this interface (via Value.StructAccessor) instead of
+// Value.GetStruct.
+type StructAccessor interface {
+	// FieldByID returns the field with the given ID, materializing
+	// however much of the struct is necessary to find it. ok is false
+	// if no such field is present.
+	FieldByID(id int16) (v Value, ok bool, err error)
+
+	// ForEach calls f once per field, in wire order, stopping at the
+	// first error f returns.
+	ForEach(f func(Field) error) error
+
+	// Len reports the number of fields, materializing the struct fully
+	// if it has not been already.
+	Len() int
+}
+
+// FieldReader supplies the fields of a struct one at a time, in wire
+// order, typically by reading directly off a protocol decoder positioned
+// just past the struct's header. Next returns ok=false, with a nil error,
+// once the struct's stop marker has been consumed.
+type FieldReader interface {
+	Next() (field Field, ok bool, err error)
+}
+
+// LazyStruct is a StructAccessor that streams its fields from a
+// FieldReader instead of requiring them all to be decoded up front. The
+// first access to a given field streams forward only as far as necessary
+// to find it, recording every field read along the way so that it - and
+// everything before it - is O(1) to access again. The first ForEach call
+// that runs past the fields already read materializes the rest of the
+// struct.
+//
+// A LazyStruct is not safe for concurrent use.
+type LazyStruct struct {
+	reader FieldReader
+	read   []Field
+	byID   map[int16]int
+	done   bool
+}
+
+var _ StructAccessor = (*LazyStruct)(nil)
+
+// NewLazyStruct returns a LazyStruct that streams its fields from r.
+func NewLazyStruct(r FieldReader) *LazyStruct {
+	return &LazyStruct{reader: r, byID: make(map[int16]int)}
+}
+
+// FieldByID implements StructAccessor.
+func (s *LazyStruct) FieldByID(id int16) (Value, bool, error) {
+	if idx, ok := s.byID[id]; ok {
+		return s.read[idx].Value, true, nil
+	}
+
+	for !s.done {
+		f, ok, err := s.reader.Next()
+		if err != nil {
+			return Value{}, false, err
+		}
+		if !ok {
+			s.done = true
+			break
+		}
+
+		s.byID[f.ID] = len(s.read)
+		s.read = append(s.read, f)
+		if f.ID == id {
+			return f.Value, true, nil
+		}
+	}
+
+	return Value{}, false, nil
+}
+
+// ForEach implements StructAccessor. The first call that exhausts the
+// reader materializes every remaining field; subsequent calls replay the
+// fields already read without touching the reader again.
+func (s *LazyStruct) ForEach(f func(Field) error) error {
+	for _, field := range s.read {
+		if err := f(field); err != nil {
+			return err
+		}
+	}
+
+	for !s.done {
+		field, ok, err := s.reader.Next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			s.done = true
+			break
+		}
+
+		s.byID[field.ID] = len(s.read)
+		s.read = append(s.read, field)
+		if err := f(field); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Len implements StructAccessor, materializing every remaining field.
+func (s *LazyStruct) Len() int {
+	s.ForEach(func(Field) error { return nil })
+	return len(s.read)
+}
+
+// ItemReader supplies the items of a List or Set one at a time, in wire
+// order, typically by reading directly off a protocol decoder. Next
+// returns ok=false, with a nil error, once every item has been read.
+type ItemReader interface {
+	Next() (item Value, ok bool, err error)
+}
+
+// NewLazyList returns a List of the given element Type and size whose
+// Items stream from r instead of requiring the whole container to be
+// decoded up front. Each item is read from r exactly once; callers that
+// need to iterate more than once should materialize the List first (for
+// example with Canonicalize).
+func NewLazyList(typ Type, size int, r ItemReader) List {
+	return List{ValueType: typ, Size: size, Items: &lazyValueList{typ: typ, size: size, reader: r}}
+}
+
+type lazyValueList struct {
+	typ    Type
+	size   int
+	reader ItemReader
+}
+
+func (l *lazyValueList) ValueType() Type { return l.typ }
+
+// Size returns the size the container was declared with on the wire. It
+// is not adjusted as items are streamed, and is -1 if the caller building
+// the list (typically a protocol decoder) didn't know it up front.
+func (l *lazyValueList) Size() int { return l.size }
+
+func (l *lazyValueList) ForEach(f func(Value) error) error {
+	for {
+		item, ok, err := l.reader.Next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		if err := f(item); err != nil {
+			return err
+		}
+	}
+}
+
+// Close releases the underlying ItemReader, if it holds resources worth
+// releasing (for example, a protocol decoder wrapping a network
+// connection). Readers that don't implement io.Closer are left alone.
+func (l *lazyValueList) Close() error {
+	if c, ok := l.reader.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// MapItemReader supplies the items of a Map one at a time, in wire order,
+// typically by reading directly off a protocol decoder. Next returns
+// ok=false, with a nil error, once every item has been read.
+type MapItemReader interface {
+	Next() (item MapItem, ok bool, err error)
+}
+
+// NewLazyMap returns a Map of the given key/value Types and size whose
+// Items stream from r instead of requiring the whole container to be
+// decoded up front. Each item is read from r exactly once; callers that
+// need to iterate more than once should materialize the Map first (for
+// example with Canonicalize).
+func NewLazyMap(keyType, valueType Type, size int, r MapItemReader) Map {
+	return Map{
+		KeyType:   keyType,
+		ValueType: valueType,
+		Size:      size,
+		Items:     &lazyMapItemList{keyType: keyType, valueType: valueType, size: size, reader: r},
+	}
+}
+
+type lazyMapItemList struct {
+	keyType, valueType Type
+	size               int
+	reader             MapItemReader
+}
+
+func (l *lazyMapItemList) KeyType() Type { return l.keyType }
+
+func (l *lazyMapItemList) ValueType() Type { return l.valueType }
+
+// Size returns the size the container was declared with on the wire. It
+// is not adjusted as items are streamed, and is -1 if the caller building
+// the map (typically a protocol decoder) didn't know it up front.
+func (l *lazyMapItemList) Size() int { return l.size }
+
+func (l *lazyMapItemList) ForEach(f func(MapItem) error) error {
+	for {
+		item, ok, err := l.reader.Next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		if err := f(item); err != nil {
+			return err
+		}
+	}
+}
+
+// Close releases the underlying MapItemReader, if it holds resources
+// worth releasing. Readers that don't implement io.Closer are left alone.
+func (l *lazyMapItemList) Close() error {
+	if c, ok := l.reader.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// streamer is implemented by a ValueList/MapItemList backing that can
+// push items to a channel as they're produced, rather than only via
+// ForEach. lazyValueList and lazyMapItemList satisfy it so that List.Stream
+// and Map.Stream forward directly to the reader instead of buffering.
+type valueStreamer interface {
+	Stream(ctx context.Context) <-chan Value
+}
+
+// Stream returns a channel that yields l's items one at a time, so a
+// caller can `for item := range list.Stream(ctx)` instead of loading the
+// whole container up front. The channel is closed once every item has
+// been sent, the underlying reader is exhausted, or ctx is canceled.
+func (l List) Stream(ctx context.Context) <-chan Value {
+	if s, ok := l.Items.(valueStreamer); ok {
+		return s.Stream(ctx)
+	}
+	return streamValues(ctx, l.Items)
+}
+
+func (l *lazyValueList) Stream(ctx context.Context) <-chan Value {
+	ch := make(chan Value)
+	go func() {
+		defer close(ch)
+		for {
+			item, ok, err := l.reader.Next()
+			if err != nil || !ok {
+				return
+			}
+			select {
+			case ch <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+func streamValues(ctx context.Context, items ValueList) <-chan Value {
+	ch := make(chan Value)
+	go func() {
+		defer close(ch)
+		items.ForEach(func(item Value) error {
+			select {
+			case ch <- item:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+	return ch
+}
+
+// mapItemStreamer is the Map analog of valueStreamer.
+type mapItemStreamer interface {
+	Stream(ctx context.Context) <-chan MapItem
+}
+
+// Stream returns a channel that yields m's items one at a time, so a
+// caller can `for item := range m.Stream(ctx)` instead of loading the
+// whole container up front. The channel is closed once every item has
+// been sent, the underlying reader is exhausted, or ctx is canceled.
+func (m Map) Stream(ctx context.Context) <-chan MapItem {
+	if s, ok := m.Items.(mapItemStreamer); ok {
+		return s.Stream(ctx)
+	}
+	return streamMapItems(ctx, m.Items)
+}
+
+func (l *lazyMapItemList) Stream(ctx context.Context) <-chan MapItem {
+	ch := make(chan MapItem)
+	go func() {
+		defer close(ch)
+		for {
+			item, ok, err := l.reader.Next()
+			if err != nil || !ok {
+				return
+			}
+			select {
+			case ch <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+func streamMapItems(ctx context.Context, items MapItemList) <-chan MapItem {
+	ch := make(chan MapItem)
+	go func() {
+		defer close(ch)
+		items.ForEach(func(item MapItem) error {
+			select {
+			case ch <- item:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+	return ch
+}
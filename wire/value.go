@@ -38,7 +38,7 @@ type Value struct {
 	ti32    int32
 	ti64    int64
 	tbinary []byte
-	tstruct Struct
+	tstruct StructAccessor
 	tmap    Map
 	tset    Set
 	tlist   List
@@ -191,9 +191,52 @@ func NewValueStruct(v Struct) Value {
 	}
 }
 
-// GetStruct gets the Struct value from a Value.
-func (v *Value) GetStruct() Struct {
-	return v.tstruct
+// NewValueLazyStruct constructs a new Value backed by a StructAccessor
+// other than the eager Struct, such as a LazyStruct reading field-by-field
+// off the wire. Accessors on the returned Value (GetStruct, TryGetStruct,
+// Accept, Walk, ...) behave identically to an eager Struct; only the cost
+// of reaching them differs.
+func NewValueLazyStruct(v StructAccessor) Value {
+	return Value{
+		typ:     TStruct,
+		tstruct: v,
+	}
+}
+
+// GetStruct gets the Struct value from a Value, materializing it into the
+// classic Struct{Fields: []Field} shape if it was built from a
+// NewValueLazyStruct accessor.
+//
+// err is non-nil if materializing a lazily-backed struct fails, for
+// example because its FieldReader hit truncated wire bytes or an I/O
+// error partway through - a real possibility when the Value came from
+// decoding an untrusted payload, so callers must check it rather than
+// treating a decode failure as a crash. The returned Struct holds
+// whatever fields were read before the error. Callers that need to avoid
+// materializing the struct at all should use Value.StructAccessor and
+// call ForEach themselves.
+func (v *Value) GetStruct() (Struct, error) {
+	if s, ok := v.tstruct.(Struct); ok {
+		return s, nil
+	}
+
+	var fields []Field
+	err := v.tstruct.ForEach(func(f Field) error {
+		fields = append(fields, f)
+		return nil
+	})
+	return Struct{Fields: fields}, err
+}
+
+// StructAccessor returns the raw accessor backing v without materializing
+// it into a Struct, so that callers who only need a field or two (or who
+// want to stream every field) can avoid the cost of building the full
+// Fields slice. ok is false if v does not hold a struct.
+func (v *Value) StructAccessor() (accessor StructAccessor, ok bool) {
+	if v.typ != TStruct {
+		return nil, false
+	}
+	return v.tstruct, true
 }
 
 // NewValueMap constructs a new Value that contains a map.
@@ -252,7 +295,11 @@ func (v Value) String() string {
 	case TBinary:
 		return fmt.Sprintf("TBinary(%v)", v.tbinary)
 	case TStruct:
-		return fmt.Sprintf("TStruct(%v)", v.tstruct)
+		s, err := v.GetStruct()
+		if err != nil {
+			return fmt.Sprintf("TStruct(<error materializing struct: %v>)", err)
+		}
+		return fmt.Sprintf("TStruct(%v)", s)
 	case TMap:
 		return fmt.Sprintf("TMap(%v)", v.tmap)
 	case TSet:
@@ -279,6 +326,31 @@ func (s Struct) fieldMap() map[int16]Value {
 	return m
 }
 
+// FieldByID implements StructAccessor.
+func (s Struct) FieldByID(id int16) (Value, bool, error) {
+	for _, f := range s.Fields {
+		if f.ID == id {
+			return f.Value, true, nil
+		}
+	}
+	return Value{}, false, nil
+}
+
+// ForEach implements StructAccessor.
+func (s Struct) ForEach(f func(Field) error) error {
+	for _, field := range s.Fields {
+		if err := f(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Len implements StructAccessor.
+func (s Struct) Len() int {
+	return len(s.Fields)
+}
+
 func (s Struct) String() string {
 	fields := make([]string, len(s.Fields))
 	for i, field := range s.Fields {
@@ -297,6 +369,49 @@ func (f Field) String() string {
 	return fmt.Sprintf("%v: %v", f.ID, f.Value)
 }
 
+// ValueList represents the items of a Set or List. Implementations may
+// hold every item already materialized in memory (see ValueListFromSlice)
+// or stream them from the wire on demand (see NewLazyList); callers should
+// only assume ForEach visits each item once, in order.
+type ValueList interface {
+	// ValueType is the Type shared by every item in the list.
+	ValueType() Type
+
+	// Size is the number of items in the list, or -1 if that count is not
+	// known up front (for example, a streaming reader that has not
+	// finished decoding).
+	Size() int
+
+	// ForEach calls f once per item, in order, stopping at the first
+	// error f returns.
+	ForEach(f func(Value) error) error
+
+	// Close releases any resources held by the list, such as an open
+	// reader. Lists backed by an in-memory slice treat Close as a no-op.
+	Close() error
+}
+
+// MapItemList is the Map analog of ValueList.
+type MapItemList interface {
+	// KeyType is the Type shared by every item's key.
+	KeyType() Type
+
+	// ValueType is the Type shared by every item's value.
+	ValueType() Type
+
+	// Size is the number of items in the list, or -1 if that count is not
+	// known up front.
+	Size() int
+
+	// ForEach calls f once per item, in order, stopping at the first
+	// error f returns.
+	ForEach(f func(MapItem) error) error
+
+	// Close releases any resources held by the list, such as an open
+	// reader. Lists backed by an in-memory slice treat Close as a no-op.
+	Close() error
+}
+
 // Set is a set of values.
 type Set struct {
 	ValueType Type
@@ -360,3 +475,62 @@ type MapItem struct {
 func (mi MapItem) String() string {
 	return fmt.Sprintf("%v: %v", mi.Key, mi.Value)
 }
+
+// ValueListFromSlice returns a ValueList backed by an in-memory slice of
+// items of the given type, for constructing a Set or List from values
+// that are already materialized (for example, the output of Canonicalize,
+// or items decoded from a non-wire encoding such as JSON or text).
+func ValueListFromSlice(typ Type, items []Value) ValueList {
+	return valueSlice{typ: typ, items: items}
+}
+
+// valueSlice is the ValueList returned by ValueListFromSlice.
+type valueSlice struct {
+	typ   Type
+	items []Value
+}
+
+func (vs valueSlice) ValueType() Type { return vs.typ }
+
+func (vs valueSlice) Size() int { return len(vs.items) }
+
+func (vs valueSlice) ForEach(f func(Value) error) error {
+	for _, v := range vs.items {
+		if err := f(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (vs valueSlice) Close() error { return nil }
+
+// MapItemListFromSlice returns a MapItemList backed by an in-memory slice
+// of items with the given key/value types, for constructing a Map from
+// items that are already materialized.
+func MapItemListFromSlice(keyType, valueType Type, items []MapItem) MapItemList {
+	return mapItemSlice{keyType: keyType, valueType: valueType, items: items}
+}
+
+// mapItemSlice is the MapItemList returned by MapItemListFromSlice.
+type mapItemSlice struct {
+	keyType, valueType Type
+	items              []MapItem
+}
+
+func (mis mapItemSlice) KeyType() Type { return mis.keyType }
+
+func (mis mapItemSlice) ValueType() Type { return mis.valueType }
+
+func (mis mapItemSlice) Size() int { return len(mis.items) }
+
+func (mis mapItemSlice) ForEach(f func(MapItem) error) error {
+	for _, item := range mis.items {
+		if err := f(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (mis mapItemSlice) Close() error { return nil }
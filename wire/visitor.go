@@ -0,0 +1,236 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package wire
+
+import "fmt"
+
+// Kind reports the type of value held inside a Value. It is an alias for
+// Type, kept separate so that callers writing reflect.Kind-style switches
+// can read "v.Kind()" rather than "v.Type()".
+func (v *Value) Kind() Type {
+	return v.typ
+}
+
+// TryGetBool returns the Bool value held inside v and true if v holds a
+// Bool, or false, false otherwise. Unlike GetBool, it never silently
+// returns the zero value for a mismatched type.
+func (v *Value) TryGetBool() (bool, bool) {
+	if v.typ != TBool {
+		return false, false
+	}
+	return v.tbool, true
+}
+
+// TryGetI8 returns the I8 value held inside v and true if v holds an I8,
+// or false, false otherwise.
+func (v *Value) TryGetI8() (int8, bool) {
+	if v.typ != TI8 {
+		return 0, false
+	}
+	return v.ti8, true
+}
+
+// TryGetDouble returns the Double value held inside v and true if v holds
+// a Double, or false, false otherwise.
+func (v *Value) TryGetDouble() (float64, bool) {
+	if v.typ != TDouble {
+		return 0, false
+	}
+	return v.tdouble, true
+}
+
+// TryGetI16 returns the I16 value held inside v and true if v holds an
+// I16, or false, false otherwise.
+func (v *Value) TryGetI16() (int16, bool) {
+	if v.typ != TI16 {
+		return 0, false
+	}
+	return v.ti16, true
+}
+
+// TryGetI32 returns the I32 value held inside v and true if v holds an
+// I32, or false, false otherwise.
+func (v *Value) TryGetI32() (int32, bool) {
+	if v.typ != TI32 {
+		return 0, false
+	}
+	return v.ti32, true
+}
+
+// TryGetI64 returns the I64 value held inside v and true if v holds an
+// I64, or false, false otherwise.
+func (v *Value) TryGetI64() (int64, bool) {
+	if v.typ != TI64 {
+		return 0, false
+	}
+	return v.ti64, true
+}
+
+// TryGetBinary returns the Binary value held inside v and true if v holds
+// a Binary, or false, false otherwise.
+func (v *Value) TryGetBinary() ([]byte, bool) {
+	if v.typ != TBinary {
+		return nil, false
+	}
+	return v.tbinary, true
+}
+
+// TryGetString returns the string held inside v and true if v holds a
+// Binary value, or false, false otherwise.
+func (v *Value) TryGetString() (string, bool) {
+	if v.typ != TBinary {
+		return "", false
+	}
+	return unsafeBytesToString(v.tbinary), true
+}
+
+// TryGetStruct returns the Struct value held inside v and true if v holds
+// a Struct and materializing it succeeds, or false otherwise. Like
+// GetStruct, it materializes a lazily-backed struct; use
+// Value.StructAccessor to avoid that cost. Callers that need to tell a
+// decode failure apart from v simply not holding a struct should call
+// GetStruct directly instead.
+func (v *Value) TryGetStruct() (Struct, bool) {
+	if v.typ != TStruct {
+		return Struct{}, false
+	}
+	s, err := v.GetStruct()
+	if err != nil {
+		return Struct{}, false
+	}
+	return s, true
+}
+
+// TryGetMap returns the Map value held inside v and true if v holds a
+// Map, or false, false otherwise.
+func (v *Value) TryGetMap() (Map, bool) {
+	if v.typ != TMap {
+		return Map{}, false
+	}
+	return v.tmap, true
+}
+
+// TryGetSet returns the Set value held inside v and true if v holds a
+// Set, or false, false otherwise.
+func (v *Value) TryGetSet() (Set, bool) {
+	if v.typ != TSet {
+		return Set{}, false
+	}
+	return v.tset, true
+}
+
+// TryGetList returns the List value held inside v and true if v holds a
+// List, or false, false otherwise.
+func (v *Value) TryGetList() (List, bool) {
+	if v.typ != TList {
+		return List{}, false
+	}
+	return v.tlist, true
+}
+
+// Visitor receives a callback for the concrete type held inside a Value
+// when passed to Value.Accept. Implementations that only care about a
+// subset of types may embed DefaultVisitor and override just those
+// methods.
+type Visitor interface {
+	VisitBool(bool) error
+	VisitI8(int8) error
+	VisitDouble(float64) error
+	VisitI16(int16) error
+	VisitI32(int32) error
+	VisitI64(int64) error
+	VisitBinary([]byte) error
+	VisitStruct(StructAccessor) error
+	VisitMap(Map) error
+	VisitSet(Set) error
+	VisitList(List) error
+}
+
+// DefaultVisitor is a Visitor whose every method returns nil. Embed it in
+// a Visitor implementation to avoid having to write out cases that are
+// irrelevant to the task at hand.
+type DefaultVisitor struct{}
+
+var _ Visitor = DefaultVisitor{}
+
+// VisitBool implements Visitor.
+func (DefaultVisitor) VisitBool(bool) error { return nil }
+
+// VisitI8 implements Visitor.
+func (DefaultVisitor) VisitI8(int8) error { return nil }
+
+// VisitDouble implements Visitor.
+func (DefaultVisitor) VisitDouble(float64) error { return nil }
+
+// VisitI16 implements Visitor.
+func (DefaultVisitor) VisitI16(int16) error { return nil }
+
+// VisitI32 implements Visitor.
+func (DefaultVisitor) VisitI32(int32) error { return nil }
+
+// VisitI64 implements Visitor.
+func (DefaultVisitor) VisitI64(int64) error { return nil }
+
+// VisitBinary implements Visitor.
+func (DefaultVisitor) VisitBinary([]byte) error { return nil }
+
+// VisitStruct implements Visitor.
+func (DefaultVisitor) VisitStruct(StructAccessor) error { return nil }
+
+// VisitMap implements Visitor.
+func (DefaultVisitor) VisitMap(Map) error { return nil }
+
+// VisitSet implements Visitor.
+func (DefaultVisitor) VisitSet(Set) error { return nil }
+
+// VisitList implements Visitor.
+func (DefaultVisitor) VisitList(List) error { return nil }
+
+// Accept dispatches to the method of visitor matching v's Kind, passing
+// along the value it holds.
+func (v *Value) Accept(visitor Visitor) error {
+	switch v.typ {
+	case TBool:
+		return visitor.VisitBool(v.tbool)
+	case TI8:
+		return visitor.VisitI8(v.ti8)
+	case TDouble:
+		return visitor.VisitDouble(v.tdouble)
+	case TI16:
+		return visitor.VisitI16(v.ti16)
+	case TI32:
+		return visitor.VisitI32(v.ti32)
+	case TI64:
+		return visitor.VisitI64(v.ti64)
+	case TBinary:
+		return visitor.VisitBinary(v.tbinary)
+	case TStruct:
+		return visitor.VisitStruct(v.tstruct)
+	case TMap:
+		return visitor.VisitMap(v.tmap)
+	case TSet:
+		return visitor.VisitSet(v.tset)
+	case TList:
+		return visitor.VisitList(v.tlist)
+	default:
+		return fmt.Errorf("unknown value type %v", v.typ)
+	}
+}
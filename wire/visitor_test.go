@@ -0,0 +1,165 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package wire
+
+import "testing"
+
+func TestTryGetMismatchedType(t *testing.T) {
+	v := NewValueI32(42)
+
+	if _, ok := v.TryGetBool(); ok {
+		t.Error("TryGetBool on a TI32 Value = ok, want !ok")
+	}
+	if _, ok := v.TryGetStruct(); ok {
+		t.Error("TryGetStruct on a TI32 Value = ok, want !ok")
+	}
+	if i, ok := v.TryGetI32(); !ok || i != 42 {
+		t.Errorf("TryGetI32 on a TI32 Value = %v, %v, want 42, true", i, ok)
+	}
+}
+
+func TestTryGetString(t *testing.T) {
+	v := NewValueString("hello")
+	s, ok := v.TryGetString()
+	if !ok || s != "hello" {
+		t.Errorf("TryGetString = %q, %v, want \"hello\", true", s, ok)
+	}
+
+	if _, ok := NewValueI32(1).TryGetString(); ok {
+		t.Error("TryGetString on a TI32 Value = ok, want !ok")
+	}
+}
+
+func TestTryGetStructMaterializationFailure(t *testing.T) {
+	v := NewValueLazyStruct(NewLazyStruct(erroringFieldReader{}))
+	if _, ok := v.TryGetStruct(); ok {
+		t.Error("TryGetStruct on a struct whose FieldReader fails = ok, want !ok")
+	}
+}
+
+type erroringFieldReader struct{}
+
+func (erroringFieldReader) Next() (Field, bool, error) {
+	return Field{}, false, errBoom
+}
+
+type acceptRecorder struct {
+	DefaultVisitor
+	kinds []Type
+}
+
+func (r *acceptRecorder) VisitBool(bool) error { r.kinds = append(r.kinds, TBool); return nil }
+func (r *acceptRecorder) VisitI32(int32) error { r.kinds = append(r.kinds, TI32); return nil }
+func (r *acceptRecorder) VisitStruct(StructAccessor) error {
+	r.kinds = append(r.kinds, TStruct)
+	return nil
+}
+
+func TestAcceptDispatchesToVisitor(t *testing.T) {
+	tests := []struct {
+		name string
+		v    Value
+		want Type
+	}{
+		{"bool", NewValueBool(true), TBool},
+		{"i32", NewValueI32(1), TI32},
+		{"struct", structOf(Field{ID: 1, Value: NewValueI32(1)}), TStruct},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &acceptRecorder{}
+			v := tt.v
+			if err := v.Accept(r); err != nil {
+				t.Fatalf("Accept: %v", err)
+			}
+			if len(r.kinds) != 1 || r.kinds[0] != tt.want {
+				t.Errorf("Accept dispatched to %v, want [%v]", r.kinds, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultVisitorReturnsNil(t *testing.T) {
+	var v DefaultVisitor
+	scalar := NewValueI32(1)
+	if err := scalar.Accept(v); err != nil {
+		t.Errorf("DefaultVisitor.VisitI32 = %v, want nil", err)
+	}
+	s := structOf(Field{ID: 1, Value: NewValueI32(1)})
+	if err := s.Accept(v); err != nil {
+		t.Errorf("DefaultVisitor.VisitStruct = %v, want nil", err)
+	}
+}
+
+func TestWalkVisitsEveryChild(t *testing.T) {
+	v := structOf(
+		Field{ID: 1, Value: NewValueI32(1)},
+		Field{ID: 2, Value: mapOf(TI32, TI32, MapItem{Key: NewValueI32(9), Value: NewValueI32(90)})},
+		Field{ID: 3, Value: listOf(TI32, NewValueI32(7), NewValueI32(8))},
+	)
+
+	type visit struct {
+		depth int
+		kind  Type
+	}
+	var visits []visit
+	if err := Walk(v, func(path []Step, child Value) error {
+		visits = append(visits, visit{depth: len(path), kind: child.Kind()})
+		return nil
+	}); err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+
+	// The root struct, its 3 fields, the map's 1 item, and the list's 2
+	// items: 1 + 3 + 1 + 2 = 7 callbacks.
+	if len(visits) != 7 {
+		t.Fatalf("Walk produced %d visits, want 7: %v", len(visits), visits)
+	}
+	if visits[0].depth != 0 || visits[0].kind != TStruct {
+		t.Errorf("first visit = %v, want the root struct at depth 0", visits[0])
+	}
+}
+
+func TestWalkStopsOnError(t *testing.T) {
+	v := structOf(Field{ID: 1, Value: NewValueI32(1)}, Field{ID: 2, Value: NewValueI32(2)})
+
+	calls := 0
+	err := Walk(v, func(path []Step, child Value) error {
+		calls++
+		if len(path) > 0 {
+			return errBoom
+		}
+		return nil
+	})
+	if err != errBoom {
+		t.Errorf("Walk returned %v, want errBoom", err)
+	}
+	if calls != 2 {
+		t.Errorf("Walk made %d calls before stopping, want 2 (root, then first field)", calls)
+	}
+}
+
+var errBoom = &boomError{}
+
+type boomError struct{}
+
+func (*boomError) Error() string { return "boom" }
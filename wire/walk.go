@@ -0,0 +1,106 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package wire
+
+// Step is a single hop in the path to a Value reached by Walk. Exactly one
+// of FieldID, Key, or Index is meaningful for a given Step, determined by
+// the container (Struct, Map, or List/Set respectively) it was produced
+// from.
+type Step struct {
+	// FieldID is the field identifier of the step, set when the step
+	// descends into a Struct field.
+	FieldID int16
+
+	// Key is the map key of the step, set when the step descends into a
+	// Map item. Key is a pointer so that Steps which do not represent a
+	// map descent can leave it nil.
+	Key *Value
+
+	// Index is the position of the step, set when the step descends into
+	// a List or Set item.
+	Index int
+}
+
+// Walk recurses through v, calling fn with the path leading to v and v
+// itself, then descending into v's children (Struct fields, Map items, Set
+// items, and List items) in turn. fn is called for v itself with an empty
+// path before any children are visited.
+//
+// Walk stops and returns the first error returned by fn.
+func Walk(v Value, fn func(path []Step, v Value) error) error {
+	return walk(nil, v, fn)
+}
+
+func walk(path []Step, v Value, fn func(path []Step, v Value) error) error {
+	if err := fn(path, v); err != nil {
+		return err
+	}
+
+	switch v.typ {
+	case TStruct:
+		var err error
+		v.tstruct.ForEach(func(field Field) error {
+			step := append(append([]Step{}, path...), Step{FieldID: field.ID})
+			err = walk(step, field.Value, fn)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	case TMap:
+		var err error
+		v.tmap.Items.ForEach(func(item MapItem) error {
+			key := item.Key
+			step := append(append([]Step{}, path...), Step{Key: &key})
+			err = walk(step, item.Value, fn)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	case TSet:
+		var err error
+		idx := 0
+		v.tset.Items.ForEach(func(item Value) error {
+			step := append(append([]Step{}, path...), Step{Index: idx})
+			idx++
+			err = walk(step, item, fn)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	case TList:
+		var err error
+		idx := 0
+		v.tlist.Items.ForEach(func(item Value) error {
+			step := append(append([]Step{}, path...), Step{Index: idx})
+			idx++
+			err = walk(step, item, fn)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package wirecmp adapts wire.Value's semantic equality to google/go-cmp,
+// so that tests already built around cmp.Diff/cmp.Equal can compare
+// wire.Values without writing their own Comparer.
+package wirecmp
+
+import (
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/r-hang/thriftrw-go/wire"
+)
+
+// Equate returns a cmp.Option that compares wire.Values with wire.Equal
+// instead of field-by-field, so that Struct field order and Set/Map item
+// order never produce a spurious diff.
+//
+//	cmp.Diff(got, want, wirecmp.Equate())
+func Equate() cmp.Option {
+	return cmp.Comparer(func(a, b wire.Value) bool {
+		return wire.Equal(a, b)
+	})
+}
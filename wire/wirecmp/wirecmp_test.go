@@ -0,0 +1,73 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package wirecmp
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/r-hang/thriftrw-go/wire"
+)
+
+func structOf(fields ...wire.Field) wire.Value {
+	return wire.NewValueStruct(wire.Struct{Fields: fields})
+}
+
+func TestEquateIgnoresStructFieldOrder(t *testing.T) {
+	a := structOf(wire.Field{ID: 1, Value: wire.NewValueI32(1)}, wire.Field{ID: 2, Value: wire.NewValueI32(2)})
+	b := structOf(wire.Field{ID: 2, Value: wire.NewValueI32(2)}, wire.Field{ID: 1, Value: wire.NewValueI32(1)})
+
+	if !cmp.Equal(a, b, Equate()) {
+		t.Errorf("cmp.Equal(a, b, Equate()) = false, want true: field order shouldn't matter")
+	}
+	if diff := cmp.Diff(a, b, Equate()); diff != "" {
+		t.Errorf("cmp.Diff(a, b, Equate()) = %q, want empty", diff)
+	}
+}
+
+func TestEquateCatchesValueMismatch(t *testing.T) {
+	a := structOf(wire.Field{ID: 1, Value: wire.NewValueI32(1)})
+	b := structOf(wire.Field{ID: 1, Value: wire.NewValueI32(2)})
+
+	if cmp.Equal(a, b, Equate()) {
+		t.Error("cmp.Equal(a, b, Equate()) = true, want false: field values differ")
+	}
+	if diff := cmp.Diff(a, b, Equate()); diff == "" {
+		t.Error("cmp.Diff(a, b, Equate()) = empty, want a reported difference")
+	}
+}
+
+func TestEquateWorksNestedInAStruct(t *testing.T) {
+	type pair struct {
+		Got, Want wire.Value
+	}
+
+	a := pair{Got: wire.NewValueI32(1), Want: wire.NewValueI32(1)}
+	b := pair{Got: wire.NewValueI32(1), Want: wire.NewValueI32(2)}
+
+	if !cmp.Equal(a, a, Equate()) {
+		t.Error("cmp.Equal(a, a, Equate()) = false, want true")
+	}
+	if cmp.Equal(a, b, Equate()) {
+		t.Error("cmp.Equal(a, b, Equate()) = true, want false")
+	}
+}
@@ -0,0 +1,495 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package wirejson marshals and unmarshals a wire.Value to and from a
+// JSON encoding modeled on Apache Thrift's TJSONProtocol: every value is a
+// two-element `[typeTag, payload]` array (a struct field or a map/set/list
+// item is no different), so the encoding is lossless for all 11 wire
+// types even though plain JSON only has numbers, strings, bools, arrays,
+// and objects.
+package wirejson
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/r-hang/thriftrw-go/wire"
+)
+
+// Marshal encodes v as TJSONProtocol-style tagged JSON.
+func Marshal(v wire.Value) ([]byte, error) {
+	tv, err := marshalValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(tv)
+}
+
+// Unmarshal decodes a wire.Value from its TJSONProtocol-style tagged JSON
+// encoding, as produced by Marshal.
+func Unmarshal(data []byte) (wire.Value, error) {
+	var raw json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return wire.Value{}, err
+	}
+	return unmarshalValue(raw)
+}
+
+// JSONValue wraps a wire.Value so that it can be embedded inside an
+// arbitrary Go struct and encoded with the standard encoding/json package,
+// for example in structured log lines.
+type JSONValue struct {
+	Value wire.Value
+}
+
+var (
+	_ json.Marshaler   = JSONValue{}
+	_ json.Unmarshaler = (*JSONValue)(nil)
+)
+
+// MarshalJSON implements json.Marshaler.
+func (j JSONValue) MarshalJSON() ([]byte, error) {
+	return Marshal(j.Value)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *JSONValue) UnmarshalJSON(data []byte) error {
+	v, err := Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	j.Value = v
+	return nil
+}
+
+// tag is the TJSONProtocol-style short type name used as the first
+// element of every encoded value.
+type tag string
+
+const (
+	tagBool   tag = "tf"
+	tagI8     tag = "i8"
+	tagDouble tag = "dbl"
+	tagI16    tag = "i16"
+	tagI32    tag = "i32"
+	tagI64    tag = "i64"
+	tagBinary tag = "bin"
+	tagStruct tag = "rec"
+	tagMap    tag = "map"
+	tagSet    tag = "set"
+	tagList   tag = "lst"
+)
+
+func tagFor(t wire.Type) (tag, error) {
+	switch t {
+	case wire.TBool:
+		return tagBool, nil
+	case wire.TI8:
+		return tagI8, nil
+	case wire.TDouble:
+		return tagDouble, nil
+	case wire.TI16:
+		return tagI16, nil
+	case wire.TI32:
+		return tagI32, nil
+	case wire.TI64:
+		return tagI64, nil
+	case wire.TBinary:
+		return tagBinary, nil
+	case wire.TStruct:
+		return tagStruct, nil
+	case wire.TMap:
+		return tagMap, nil
+	case wire.TSet:
+		return tagSet, nil
+	case wire.TList:
+		return tagList, nil
+	default:
+		return "", fmt.Errorf("wirejson: unknown wire type %v", t)
+	}
+}
+
+func typeFor(t tag) (wire.Type, error) {
+	switch t {
+	case tagBool:
+		return wire.TBool, nil
+	case tagI8:
+		return wire.TI8, nil
+	case tagDouble:
+		return wire.TDouble, nil
+	case tagI16:
+		return wire.TI16, nil
+	case tagI32:
+		return wire.TI32, nil
+	case tagI64:
+		return wire.TI64, nil
+	case tagBinary:
+		return wire.TBinary, nil
+	case tagStruct:
+		return wire.TStruct, nil
+	case tagMap:
+		return wire.TMap, nil
+	case tagSet:
+		return wire.TSet, nil
+	case tagList:
+		return wire.TList, nil
+	default:
+		return 0, fmt.Errorf("wirejson: unknown type tag %q", t)
+	}
+}
+
+// taggedValue is the [tag, payload] shape every value round-trips
+// through.
+type taggedValue struct {
+	Tag     tag
+	Payload interface{}
+}
+
+func (tv taggedValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal([2]interface{}{tv.Tag, tv.Payload})
+}
+
+func marshalValue(v wire.Value) (taggedValue, error) {
+	switch v.Kind() {
+	case wire.TBool:
+		b, _ := v.TryGetBool()
+		return taggedValue{tagBool, b}, nil
+	case wire.TI8:
+		i, _ := v.TryGetI8()
+		return taggedValue{tagI8, i}, nil
+	case wire.TDouble:
+		d, _ := v.TryGetDouble()
+		return taggedValue{tagDouble, marshalDouble(d)}, nil
+	case wire.TI16:
+		i, _ := v.TryGetI16()
+		return taggedValue{tagI16, i}, nil
+	case wire.TI32:
+		i, _ := v.TryGetI32()
+		return taggedValue{tagI32, i}, nil
+	case wire.TI64:
+		i, _ := v.TryGetI64()
+		// Encoded as a string: JSON numbers are IEEE-754 doubles and
+		// cannot represent every int64 exactly.
+		return taggedValue{tagI64, strconv.FormatInt(i, 10)}, nil
+	case wire.TBinary:
+		b, _ := v.TryGetBinary()
+		return taggedValue{tagBinary, base64.StdEncoding.EncodeToString(b)}, nil
+	case wire.TStruct:
+		s, err := v.GetStruct()
+		if err != nil {
+			return taggedValue{}, fmt.Errorf("wirejson: marshal struct: %w", err)
+		}
+		fields := make(map[string]taggedValue, len(s.Fields))
+		for _, f := range s.Fields {
+			fv, err := marshalValue(f.Value)
+			if err != nil {
+				return taggedValue{}, err
+			}
+			fields[strconv.Itoa(int(f.ID))] = fv
+		}
+		return taggedValue{tagStruct, fields}, nil
+	case wire.TMap:
+		m := v.GetMap()
+		ktag, _ := tagFor(m.KeyType)
+		vtag, _ := tagFor(m.ValueType)
+		items := make([][2]taggedValue, 0, m.Size)
+		var itemErr error
+		m.Items.ForEach(func(item wire.MapItem) error {
+			kv, err := marshalValue(item.Key)
+			if err != nil {
+				itemErr = err
+				return err
+			}
+			vv, err := marshalValue(item.Value)
+			if err != nil {
+				itemErr = err
+				return err
+			}
+			items = append(items, [2]taggedValue{kv, vv})
+			return nil
+		})
+		if itemErr != nil {
+			return taggedValue{}, itemErr
+		}
+		return taggedValue{tagMap, jsonMap{KeyType: ktag, ValueType: vtag, Items: items}}, nil
+	case wire.TSet:
+		s := v.GetSet()
+		vtag, _ := tagFor(s.ValueType)
+		items, err := marshalItems(s.Items, s.Size)
+		if err != nil {
+			return taggedValue{}, err
+		}
+		return taggedValue{tagSet, jsonContainer{ValueType: vtag, Items: items}}, nil
+	case wire.TList:
+		l := v.GetList()
+		vtag, _ := tagFor(l.ValueType)
+		items, err := marshalItems(l.Items, l.Size)
+		if err != nil {
+			return taggedValue{}, err
+		}
+		return taggedValue{tagList, jsonContainer{ValueType: vtag, Items: items}}, nil
+	default:
+		return taggedValue{}, fmt.Errorf("wirejson: unknown wire type %v", v.Kind())
+	}
+}
+
+func marshalItems(list wire.ValueList, size int) ([]taggedValue, error) {
+	items := make([]taggedValue, 0, size)
+	var itemErr error
+	list.ForEach(func(item wire.Value) error {
+		tv, err := marshalValue(item)
+		if err != nil {
+			itemErr = err
+			return err
+		}
+		items = append(items, tv)
+		return nil
+	})
+	if itemErr != nil {
+		return nil, itemErr
+	}
+	return items, nil
+}
+
+// marshalDouble encodes a float64, falling back to TJSONProtocol's string
+// spellings for the values JSON cannot represent natively.
+func marshalDouble(d float64) interface{} {
+	switch {
+	case d != d: // NaN
+		return "NaN"
+	case d > 1.7976931348623157e+308:
+		return "Infinity"
+	case d < -1.7976931348623157e+308:
+		return "-Infinity"
+	default:
+		return d
+	}
+}
+
+type jsonContainer struct {
+	ValueType tag
+	Items     []taggedValue
+}
+
+func (c jsonContainer) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		VType tag           `json:"vtype"`
+		Items []taggedValue `json:"items"`
+	}{c.ValueType, c.Items})
+}
+
+type jsonMap struct {
+	KeyType   tag
+	ValueType tag
+	Items     [][2]taggedValue
+}
+
+func (m jsonMap) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		KType tag              `json:"ktype"`
+		VType tag              `json:"vtype"`
+		Items [][2]taggedValue `json:"items"`
+	}{m.KeyType, m.ValueType, m.Items})
+}
+
+func unmarshalValue(raw json.RawMessage) (wire.Value, error) {
+	var pair [2]json.RawMessage
+	if err := json.Unmarshal(raw, &pair); err != nil {
+		return wire.Value{}, fmt.Errorf("wirejson: expected a [tag, payload] array: %w", err)
+	}
+
+	var t tag
+	if err := json.Unmarshal(pair[0], &t); err != nil {
+		return wire.Value{}, fmt.Errorf("wirejson: invalid type tag: %w", err)
+	}
+	payload := pair[1]
+
+	switch t {
+	case tagBool:
+		var b bool
+		if err := json.Unmarshal(payload, &b); err != nil {
+			return wire.Value{}, err
+		}
+		return wire.NewValueBool(b), nil
+	case tagI8:
+		var i int8
+		if err := json.Unmarshal(payload, &i); err != nil {
+			return wire.Value{}, err
+		}
+		return wire.NewValueI8(i), nil
+	case tagDouble:
+		d, err := unmarshalDouble(payload)
+		if err != nil {
+			return wire.Value{}, err
+		}
+		return wire.NewValueDouble(d), nil
+	case tagI16:
+		var i int16
+		if err := json.Unmarshal(payload, &i); err != nil {
+			return wire.Value{}, err
+		}
+		return wire.NewValueI16(i), nil
+	case tagI32:
+		var i int32
+		if err := json.Unmarshal(payload, &i); err != nil {
+			return wire.Value{}, err
+		}
+		return wire.NewValueI32(i), nil
+	case tagI64:
+		var s string
+		if err := json.Unmarshal(payload, &s); err != nil {
+			return wire.Value{}, err
+		}
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return wire.Value{}, fmt.Errorf("wirejson: invalid i64 %q: %w", s, err)
+		}
+		return wire.NewValueI64(i), nil
+	case tagBinary:
+		var s string
+		if err := json.Unmarshal(payload, &s); err != nil {
+			return wire.Value{}, err
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return wire.Value{}, fmt.Errorf("wirejson: invalid base64 binary: %w", err)
+		}
+		return wire.NewValueBinary(b), nil
+	case tagStruct:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(payload, &fields); err != nil {
+			return wire.Value{}, err
+		}
+		out := make([]wire.Field, 0, len(fields))
+		for idStr, raw := range fields {
+			id, err := strconv.ParseInt(idStr, 10, 16)
+			if err != nil {
+				return wire.Value{}, fmt.Errorf("wirejson: invalid field id %q: %w", idStr, err)
+			}
+			fv, err := unmarshalValue(raw)
+			if err != nil {
+				return wire.Value{}, err
+			}
+			out = append(out, wire.Field{ID: int16(id), Value: fv})
+		}
+		return wire.NewValueStruct(wire.Struct{Fields: out}), nil
+	case tagMap:
+		var m struct {
+			KType tag                  `json:"ktype"`
+			VType tag                  `json:"vtype"`
+			Items [][2]json.RawMessage `json:"items"`
+		}
+		if err := json.Unmarshal(payload, &m); err != nil {
+			return wire.Value{}, err
+		}
+		kt, err := typeFor(m.KType)
+		if err != nil {
+			return wire.Value{}, err
+		}
+		vt, err := typeFor(m.VType)
+		if err != nil {
+			return wire.Value{}, err
+		}
+		items := make([]wire.MapItem, 0, len(m.Items))
+		for _, pair := range m.Items {
+			k, err := unmarshalValue(pair[0])
+			if err != nil {
+				return wire.Value{}, err
+			}
+			v, err := unmarshalValue(pair[1])
+			if err != nil {
+				return wire.Value{}, err
+			}
+			items = append(items, wire.MapItem{Key: k, Value: v})
+		}
+		return wire.NewValueMap(wire.Map{
+			KeyType:   kt,
+			ValueType: vt,
+			Size:      len(items),
+			Items:     wire.MapItemListFromSlice(kt, vt, items),
+		}), nil
+	case tagSet, tagList:
+		var c struct {
+			VType tag               `json:"vtype"`
+			Items []json.RawMessage `json:"items"`
+		}
+		if err := json.Unmarshal(payload, &c); err != nil {
+			return wire.Value{}, err
+		}
+		vt, err := typeFor(c.VType)
+		if err != nil {
+			return wire.Value{}, err
+		}
+		items := make([]wire.Value, 0, len(c.Items))
+		for _, raw := range c.Items {
+			iv, err := unmarshalValue(raw)
+			if err != nil {
+				return wire.Value{}, err
+			}
+			items = append(items, iv)
+		}
+		if t == tagSet {
+			return wire.NewValueSet(wire.Set{ValueType: vt, Size: len(items), Items: wire.ValueListFromSlice(vt, items)}), nil
+		}
+		return wire.NewValueList(wire.List{ValueType: vt, Size: len(items), Items: wire.ValueListFromSlice(vt, items)}), nil
+	default:
+		return wire.Value{}, fmt.Errorf("wirejson: unknown type tag %q", t)
+	}
+}
+
+func unmarshalDouble(payload json.RawMessage) (float64, error) {
+	var s string
+	if err := json.Unmarshal(payload, &s); err == nil {
+		switch s {
+		case "NaN":
+			return nan(), nil
+		case "Infinity":
+			return posInf(), nil
+		case "-Infinity":
+			return negInf(), nil
+		default:
+			return strconv.ParseFloat(s, 64)
+		}
+	}
+
+	var f float64
+	err := json.Unmarshal(payload, &f)
+	return f, err
+}
+
+func nan() float64 {
+	var zero float64
+	return zero / zero
+}
+
+func posInf() float64 {
+	return 1 / zeroFloat()
+}
+
+func negInf() float64 {
+	return -1 / zeroFloat()
+}
+
+func zeroFloat() float64 {
+	var zero float64
+	return zero
+}
@@ -0,0 +1,513 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package wiretext marshals and unmarshals a wire.Value to and from a
+// human-friendly text form matching the shape of wire.Value.String(), for
+// example:
+//
+//	TStruct({1: TI32(42), 2: TBinary(0x68656c6c6f)})
+//	[]TI32{TI32(1), TI32(2), TI32(3)}
+//
+// Unlike Value.String(), the grammar here is fully specified and has a
+// corresponding parser, so it can round-trip: Marshal(v) followed by
+// Unmarshal produces a Value Equal to v.
+package wiretext
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/r-hang/thriftrw-go/wire"
+)
+
+// Marshal renders v in wiretext form. It returns an error if v is a
+// lazily-backed struct whose materialization fails.
+func Marshal(v wire.Value) (string, error) {
+	var sb strings.Builder
+	if err := writeValue(&sb, v); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func writeValue(sb *strings.Builder, v wire.Value) error {
+	switch v.Kind() {
+	case wire.TBool:
+		b, _ := v.TryGetBool()
+		fmt.Fprintf(sb, "TBool(%v)", b)
+	case wire.TI8:
+		i, _ := v.TryGetI8()
+		fmt.Fprintf(sb, "TI8(%d)", i)
+	case wire.TDouble:
+		d, _ := v.TryGetDouble()
+		fmt.Fprintf(sb, "TDouble(%v)", d)
+	case wire.TI16:
+		i, _ := v.TryGetI16()
+		fmt.Fprintf(sb, "TI16(%d)", i)
+	case wire.TI32:
+		i, _ := v.TryGetI32()
+		fmt.Fprintf(sb, "TI32(%d)", i)
+	case wire.TI64:
+		i, _ := v.TryGetI64()
+		fmt.Fprintf(sb, "TI64(%d)", i)
+	case wire.TBinary:
+		b, _ := v.TryGetBinary()
+		fmt.Fprintf(sb, "TBinary(0x%x)", b)
+	case wire.TStruct:
+		s, err := v.GetStruct()
+		if err != nil {
+			return fmt.Errorf("wiretext: marshal struct: %w", err)
+		}
+		sb.WriteString("TStruct({")
+		for i, f := range s.Fields {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			fmt.Fprintf(sb, "%d: ", f.ID)
+			if err := writeValue(sb, f.Value); err != nil {
+				return err
+			}
+		}
+		sb.WriteString("})")
+	case wire.TMap:
+		m := v.GetMap()
+		fmt.Fprintf(sb, "map[%s]%s{", typeName(m.KeyType), typeName(m.ValueType))
+		first := true
+		var itemErr error
+		m.Items.ForEach(func(item wire.MapItem) error {
+			if !first {
+				sb.WriteString(", ")
+			}
+			first = false
+			if err := writeValue(sb, item.Key); err != nil {
+				itemErr = err
+				return err
+			}
+			sb.WriteString(": ")
+			if err := writeValue(sb, item.Value); err != nil {
+				itemErr = err
+				return err
+			}
+			return nil
+		})
+		if itemErr != nil {
+			return itemErr
+		}
+		sb.WriteString("}")
+	case wire.TSet:
+		s := v.GetSet()
+		fmt.Fprintf(sb, "[set]%s{", typeName(s.ValueType))
+		if err := writeItems(sb, s.Items); err != nil {
+			return err
+		}
+		sb.WriteString("}")
+	case wire.TList:
+		l := v.GetList()
+		fmt.Fprintf(sb, "[]%s{", typeName(l.ValueType))
+		if err := writeItems(sb, l.Items); err != nil {
+			return err
+		}
+		sb.WriteString("}")
+	default:
+		return fmt.Errorf("wiretext: unknown wire type %v", v.Kind())
+	}
+	return nil
+}
+
+func writeItems(sb *strings.Builder, items wire.ValueList) error {
+	first := true
+	var itemErr error
+	items.ForEach(func(item wire.Value) error {
+		if !first {
+			sb.WriteString(", ")
+		}
+		first = false
+		if err := writeValue(sb, item); err != nil {
+			itemErr = err
+			return err
+		}
+		return nil
+	})
+	return itemErr
+}
+
+var typeNames = map[wire.Type]string{
+	wire.TBool:   "TBool",
+	wire.TI8:     "TI8",
+	wire.TDouble: "TDouble",
+	wire.TI16:    "TI16",
+	wire.TI32:    "TI32",
+	wire.TI64:    "TI64",
+	wire.TBinary: "TBinary",
+	wire.TStruct: "TStruct",
+	wire.TMap:    "TMap",
+	wire.TSet:    "TSet",
+	wire.TList:   "TList",
+}
+
+var namesToType = func() map[string]wire.Type {
+	m := make(map[string]wire.Type, len(typeNames))
+	for t, name := range typeNames {
+		m[name] = t
+	}
+	return m
+}()
+
+func typeName(t wire.Type) string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("Type(%d)", int(t))
+}
+
+// Unmarshal parses s, as produced by Marshal, into a wire.Value.
+func Unmarshal(s string) (wire.Value, error) {
+	p := &parser{input: s}
+	v, err := p.parseValue()
+	if err != nil {
+		return wire.Value{}, err
+	}
+	p.skipSpace()
+	if p.pos != len(p.input) {
+		return wire.Value{}, fmt.Errorf("wiretext: unexpected trailing input %q", p.input[p.pos:])
+	}
+	return v, nil
+}
+
+type parser struct {
+	input string
+	pos   int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("wiretext: at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t' || p.input[p.pos] == '\n') {
+		p.pos++
+	}
+}
+
+func (p *parser) consume(tok string) error {
+	p.skipSpace()
+	if !strings.HasPrefix(p.input[p.pos:], tok) {
+		return p.errorf("expected %q", tok)
+	}
+	p.pos += len(tok)
+	return nil
+}
+
+func (p *parser) peekIdent() string {
+	p.skipSpace()
+	start := p.pos
+	i := p.pos
+	for i < len(p.input) && (isAlnum(p.input[i])) {
+		i++
+	}
+	return p.input[start:i]
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func (p *parser) parseValue() (wire.Value, error) {
+	p.skipSpace()
+
+	switch {
+	case strings.HasPrefix(p.input[p.pos:], "map["):
+		return p.parseMap()
+	case strings.HasPrefix(p.input[p.pos:], "[set]"):
+		return p.parseSet()
+	case strings.HasPrefix(p.input[p.pos:], "[]"):
+		return p.parseList()
+	}
+
+	name := p.peekIdent()
+	typ, ok := namesToType[name]
+	if !ok {
+		return wire.Value{}, p.errorf("unknown type name %q", name)
+	}
+	p.pos += len(name)
+	if err := p.consume("("); err != nil {
+		return wire.Value{}, err
+	}
+
+	var v wire.Value
+	var err error
+	switch typ {
+	case wire.TBool:
+		ident := p.peekIdent()
+		p.pos += len(ident)
+		v = wire.NewValueBool(ident == "true")
+	case wire.TI8:
+		v, err = p.parseIntLiteral(func(n int64) wire.Value { return wire.NewValueI8(int8(n)) })
+	case wire.TDouble:
+		v, err = p.parseFloatLiteral()
+	case wire.TI16:
+		v, err = p.parseIntLiteral(func(n int64) wire.Value { return wire.NewValueI16(int16(n)) })
+	case wire.TI32:
+		v, err = p.parseIntLiteral(func(n int64) wire.Value { return wire.NewValueI32(int32(n)) })
+	case wire.TI64:
+		v, err = p.parseIntLiteral(func(n int64) wire.Value { return wire.NewValueI64(n) })
+	case wire.TBinary:
+		v, err = p.parseHexLiteral()
+	case wire.TStruct:
+		v, err = p.parseStructBody()
+	default:
+		return wire.Value{}, p.errorf("%q cannot appear as a scalar", name)
+	}
+	if err != nil {
+		return wire.Value{}, err
+	}
+	if err := p.consume(")"); err != nil {
+		return wire.Value{}, err
+	}
+	return v, nil
+}
+
+func (p *parser) parseIntLiteral(build func(int64) wire.Value) (wire.Value, error) {
+	p.skipSpace()
+	start := p.pos
+	if p.pos < len(p.input) && (p.input[p.pos] == '-' || p.input[p.pos] == '+') {
+		p.pos++
+	}
+	for p.pos < len(p.input) && p.input[p.pos] >= '0' && p.input[p.pos] <= '9' {
+		p.pos++
+	}
+	n, err := strconv.ParseInt(p.input[start:p.pos], 10, 64)
+	if err != nil {
+		return wire.Value{}, p.errorf("invalid integer: %v", err)
+	}
+	return build(n), nil
+}
+
+func (p *parser) parseFloatLiteral() (wire.Value, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.input) && strings.ContainsRune("+-0123456789.eE", rune(p.input[p.pos])) {
+		p.pos++
+	}
+	f, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+	if err != nil {
+		return wire.Value{}, p.errorf("invalid double: %v", err)
+	}
+	return wire.NewValueDouble(f), nil
+}
+
+func (p *parser) parseHexLiteral() (wire.Value, error) {
+	if err := p.consume("0x"); err != nil {
+		return wire.Value{}, err
+	}
+	start := p.pos
+	for p.pos < len(p.input) && isHexDigit(p.input[p.pos]) {
+		p.pos++
+	}
+	b, err := decodeHex(p.input[start:p.pos])
+	if err != nil {
+		return wire.Value{}, p.errorf("invalid hex binary: %v", err)
+	}
+	return wire.NewValueBinary(b), nil
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+func decodeHex(s string) ([]byte, error) {
+	if len(s)%2 != 0 {
+		return nil, fmt.Errorf("odd-length hex string %q", s)
+	}
+	out := make([]byte, len(s)/2)
+	for i := range out {
+		hi, err := strconv.ParseUint(s[2*i:2*i+1], 16, 8)
+		if err != nil {
+			return nil, err
+		}
+		lo, err := strconv.ParseUint(s[2*i+1:2*i+2], 16, 8)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = byte(hi<<4 | lo)
+	}
+	return out, nil
+}
+
+func (p *parser) parseStructBody() (wire.Value, error) {
+	if err := p.consume("{"); err != nil {
+		return wire.Value{}, err
+	}
+
+	var fields []wire.Field
+	p.skipSpace()
+	for p.pos < len(p.input) && p.input[p.pos] != '}' {
+		if len(fields) > 0 {
+			if err := p.consume(","); err != nil {
+				return wire.Value{}, err
+			}
+		}
+
+		id, err := p.parseFieldID()
+		if err != nil {
+			return wire.Value{}, err
+		}
+		if err := p.consume(":"); err != nil {
+			return wire.Value{}, err
+		}
+		v, err := p.parseValue()
+		if err != nil {
+			return wire.Value{}, err
+		}
+
+		fields = append(fields, wire.Field{ID: id, Value: v})
+		p.skipSpace()
+	}
+	if err := p.consume("}"); err != nil {
+		return wire.Value{}, err
+	}
+	return wire.NewValueStruct(wire.Struct{Fields: fields}), nil
+}
+
+func (p *parser) parseFieldID() (int16, error) {
+	p.skipSpace()
+	start := p.pos
+	if p.pos < len(p.input) && p.input[p.pos] == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.input) && p.input[p.pos] >= '0' && p.input[p.pos] <= '9' {
+		p.pos++
+	}
+	n, err := strconv.ParseInt(p.input[start:p.pos], 10, 16)
+	if err != nil {
+		return 0, p.errorf("invalid field id: %v", err)
+	}
+	return int16(n), nil
+}
+
+func (p *parser) parseMap() (wire.Value, error) {
+	if err := p.consume("map["); err != nil {
+		return wire.Value{}, err
+	}
+	kt, err := p.parseTypeName()
+	if err != nil {
+		return wire.Value{}, err
+	}
+	if err := p.consume("]"); err != nil {
+		return wire.Value{}, err
+	}
+	vt, err := p.parseTypeName()
+	if err != nil {
+		return wire.Value{}, err
+	}
+	if err := p.consume("{"); err != nil {
+		return wire.Value{}, err
+	}
+
+	var items []wire.MapItem
+	p.skipSpace()
+	for p.pos < len(p.input) && p.input[p.pos] != '}' {
+		if len(items) > 0 {
+			if err := p.consume(","); err != nil {
+				return wire.Value{}, err
+			}
+		}
+		k, err := p.parseValue()
+		if err != nil {
+			return wire.Value{}, err
+		}
+		if err := p.consume(":"); err != nil {
+			return wire.Value{}, err
+		}
+		v, err := p.parseValue()
+		if err != nil {
+			return wire.Value{}, err
+		}
+		items = append(items, wire.MapItem{Key: k, Value: v})
+		p.skipSpace()
+	}
+	if err := p.consume("}"); err != nil {
+		return wire.Value{}, err
+	}
+	return wire.NewValueMap(wire.Map{KeyType: kt, ValueType: vt, Size: len(items), Items: wire.MapItemListFromSlice(kt, vt, items)}), nil
+}
+
+func (p *parser) parseSet() (wire.Value, error) {
+	if err := p.consume("[set]"); err != nil {
+		return wire.Value{}, err
+	}
+	vt, items, err := p.parseTypedItemList()
+	if err != nil {
+		return wire.Value{}, err
+	}
+	return wire.NewValueSet(wire.Set{ValueType: vt, Size: len(items), Items: wire.ValueListFromSlice(vt, items)}), nil
+}
+
+func (p *parser) parseList() (wire.Value, error) {
+	if err := p.consume("[]"); err != nil {
+		return wire.Value{}, err
+	}
+	vt, items, err := p.parseTypedItemList()
+	if err != nil {
+		return wire.Value{}, err
+	}
+	return wire.NewValueList(wire.List{ValueType: vt, Size: len(items), Items: wire.ValueListFromSlice(vt, items)}), nil
+}
+
+func (p *parser) parseTypedItemList() (wire.Type, []wire.Value, error) {
+	vt, err := p.parseTypeName()
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := p.consume("{"); err != nil {
+		return 0, nil, err
+	}
+
+	var items []wire.Value
+	p.skipSpace()
+	for p.pos < len(p.input) && p.input[p.pos] != '}' {
+		if len(items) > 0 {
+			if err := p.consume(","); err != nil {
+				return 0, nil, err
+			}
+		}
+		v, err := p.parseValue()
+		if err != nil {
+			return 0, nil, err
+		}
+		items = append(items, v)
+		p.skipSpace()
+	}
+	if err := p.consume("}"); err != nil {
+		return 0, nil, err
+	}
+	return vt, items, nil
+}
+
+func (p *parser) parseTypeName() (wire.Type, error) {
+	name := p.peekIdent()
+	t, ok := namesToType[name]
+	if !ok {
+		return 0, p.errorf("unknown type name %q", name)
+	}
+	p.pos += len(name)
+	return t, nil
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package wiretext
+
+import (
+	"testing"
+
+	"github.com/r-hang/thriftrw-go/wire"
+)
+
+func TestMarshalUnmarshalRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		v    wire.Value
+	}{
+		{"bool", wire.NewValueBool(true)},
+		{"i8", wire.NewValueI8(-12)},
+		{"double", wire.NewValueDouble(3.5)},
+		{"i16", wire.NewValueI16(-1000)},
+		{"i32", wire.NewValueI32(123456)},
+		{"i64", wire.NewValueI64(-9223372036854775808)},
+		{"binary", wire.NewValueBinary([]byte{0x00, 0x01, 0xff})},
+		{
+			"struct",
+			wire.NewValueStruct(wire.Struct{Fields: []wire.Field{
+				{ID: 1, Value: wire.NewValueI32(42)},
+				{ID: -2, Value: wire.NewValueBinary([]byte("x"))},
+			}}),
+		},
+		{
+			"map",
+			wire.NewValueMap(wire.Map{
+				KeyType:   wire.TI32,
+				ValueType: wire.TBinary,
+				Size:      2,
+				Items: wire.MapItemListFromSlice(wire.TI32, wire.TBinary, []wire.MapItem{
+					{Key: wire.NewValueI32(1), Value: wire.NewValueBinary([]byte("a"))},
+					{Key: wire.NewValueI32(2), Value: wire.NewValueBinary([]byte("b"))},
+				}),
+			}),
+		},
+		{
+			"set",
+			wire.NewValueSet(wire.Set{
+				ValueType: wire.TI32,
+				Size:      2,
+				Items:     wire.ValueListFromSlice(wire.TI32, []wire.Value{wire.NewValueI32(1), wire.NewValueI32(2)}),
+			}),
+		},
+		{
+			"list",
+			wire.NewValueList(wire.List{
+				ValueType: wire.TI32,
+				Size:      2,
+				Items:     wire.ValueListFromSlice(wire.TI32, []wire.Value{wire.NewValueI32(1), wire.NewValueI32(2)}),
+			}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Marshal(tt.v)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+
+			got, err := Unmarshal(s)
+			if err != nil {
+				t.Fatalf("Unmarshal(%s): %v", s, err)
+			}
+
+			if !wire.Equal(got, tt.v) {
+				t.Errorf("round-trip mismatch: got %v, want %v (wiretext: %s)", got, tt.v, s)
+			}
+		})
+	}
+}
+
+func TestUnmarshalRejectsTrailingInput(t *testing.T) {
+	if _, err := Unmarshal("TI32(1) garbage"); err == nil {
+		t.Error("Unmarshal with trailing input = nil error, want an error")
+	}
+}
+
+func TestUnmarshalRejectsUnknownType(t *testing.T) {
+	if _, err := Unmarshal("TFrobnicate(1)"); err == nil {
+		t.Error("Unmarshal with an unknown type name = nil error, want an error")
+	}
+}
+
+func TestUnmarshalRejectsMalformedStruct(t *testing.T) {
+	if _, err := Unmarshal("TStruct({1 TI32(1)})"); err == nil {
+		t.Error("Unmarshal with a missing ':' in a struct field = nil error, want an error")
+	}
+}